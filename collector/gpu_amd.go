@@ -0,0 +1,232 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !noamdgpu
+
+package collector
+
+import (
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type amdgpuCollector struct {
+	amdgpuInfo              *prometheus.Desc
+	amdgpuTemperature       *prometheus.Desc
+	amdgpuPowerUsage        *prometheus.Desc
+	amdgpuFanSpeed          *prometheus.Desc
+	amdgpuMemoryTotal       *prometheus.Desc
+	amdgpuMemoryUsed        *prometheus.Desc
+	amdgpuUtilizationMemory *prometheus.Desc
+	amdgpuUtilizationGPU    *prometheus.Desc
+	amdgpuClockSCLK         *prometheus.Desc
+	amdgpuClockMCLK         *prometheus.Desc
+	logger                  log.Logger
+}
+
+type amdgpuDevice struct {
+	MinorNumber       string
+	Name              string
+	UUID              string
+	DriverVersion     string
+	Temperature       float64
+	PowerUsage        float64
+	FanSpeed          float64
+	MemoryTotal       float64
+	MemoryUsed        float64
+	UtilizationMemory float64
+	UtilizationGPU    float64
+	ClockSCLK         float64
+	ClockMCLK         float64
+}
+
+func init() {
+	registerCollector("amdgpu", defaultDisabled, NewAMDGPUCollector)
+}
+
+// NewAMDGPUCollector returns a new Collector exposing AMD GPU stats via rocm-smi.
+func NewAMDGPUCollector(logger log.Logger) (Collector, error) {
+	subsystem := "amdgpu"
+	labels := []string{"minornumber", "uuid"}
+	return &amdgpuCollector{
+		amdgpuInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"A constant 1-valued metric with a label for each piece of AMD GPU device/driver metadata",
+			[]string{"uuid", "name", "driver_version"}, nil,
+		),
+		amdgpuTemperature: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "temperature_celsius"),
+			"Temperature of AMD GPU device in system",
+			labels, nil,
+		),
+		amdgpuPowerUsage: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "power_usage_watts"),
+			"Power Usage of AMD GPU device in system",
+			labels, nil,
+		),
+		amdgpuFanSpeed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "fan_speed_ratio"),
+			"Fan Speed of AMD GPU device in system, as a ratio between 0 and 1",
+			labels, nil,
+		),
+		amdgpuMemoryTotal: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_total_bytes"),
+			"Memory Total of AMD GPU device in system",
+			labels, nil,
+		),
+		amdgpuMemoryUsed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_used_bytes"),
+			"Memory Used of AMD GPU device in system",
+			labels, nil,
+		),
+		amdgpuUtilizationMemory: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "memory_utilization_ratio"),
+			"Memory Busy of AMD GPU device in system, as a ratio between 0 and 1",
+			labels, nil,
+		),
+		amdgpuUtilizationGPU: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "utilization_ratio"),
+			"GPU Busy of AMD GPU device in system, as a ratio between 0 and 1",
+			labels, nil,
+		),
+		amdgpuClockSCLK: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "clock_sclk_hertz"),
+			"Current GPU (SCLK) clock speed of AMD GPU device in system",
+			labels, nil,
+		),
+		amdgpuClockMCLK: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "clock_mclk_hertz"),
+			"Current Memory (MCLK) clock speed of AMD GPU device in system",
+			labels, nil,
+		),
+		logger: logger,
+	}, nil
+}
+
+func (a *amdgpuCollector) Update(ch chan<- prometheus.Metric) error {
+	devices, err := collectAMDGPUMetrics()
+	if err != nil {
+		level.Debug(a.logger).Log("msg", "amdgpu information is unavailable to collect", "err", err)
+		return nil
+	}
+
+	for _, metrics := range devices {
+		labelValues := []string{metrics.MinorNumber, metrics.UUID}
+
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuInfo, prometheus.GaugeValue, 1, metrics.UUID, metrics.Name, metrics.DriverVersion)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuTemperature, prometheus.GaugeValue, metrics.Temperature, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuPowerUsage, prometheus.GaugeValue, metrics.PowerUsage, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuFanSpeed, prometheus.GaugeValue, metrics.FanSpeed/100, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuMemoryTotal, prometheus.GaugeValue, metrics.MemoryTotal, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuMemoryUsed, prometheus.GaugeValue, metrics.MemoryUsed, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuUtilizationMemory, prometheus.GaugeValue, metrics.UtilizationMemory/100, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuUtilizationGPU, prometheus.GaugeValue, metrics.UtilizationGPU/100, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuClockSCLK, prometheus.GaugeValue, metrics.ClockSCLK, labelValues...)
+		ch <- prometheus.MustNewConstMetric(
+			a.amdgpuClockMCLK, prometheus.GaugeValue, metrics.ClockMCLK, labelValues...)
+	}
+
+	return nil
+}
+
+// rocmSMICard mirrors the subset of `rocm-smi --showallinfo --json` fields we care about.
+// rocm-smi emits keys that vary slightly across ROCm releases, so every field is looked
+// up defensively and defaulted to zero/empty when absent.
+type rocmSMICard map[string]string
+
+// rocmSMICardKey matches the per-GPU top-level keys in rocm-smi's JSON output (e.g.
+// "card0"); some ROCm releases also emit non-card metadata blocks at the same level,
+// which this excludes so they don't turn into bogus devices with an empty minornumber.
+var rocmSMICardKey = regexp.MustCompile(`^card\d+$`)
+
+func collectAMDGPUMetrics() ([]amdgpuDevice, error) {
+	out, err := exec.Command("rocm-smi", "--showallinfo", "--json").Output()
+	if err != nil {
+		return nil, fmt.Errorf("could not execute rocm-smi: %w", err)
+	}
+
+	return parseRocmSMIOutput(out)
+}
+
+// parseRocmSMIOutput turns the JSON produced by `rocm-smi --showallinfo --json` into
+// one amdgpuDevice per card, split out of collectAMDGPUMetrics so it can be unit
+// tested against sample output without shelling out to rocm-smi.
+func parseRocmSMIOutput(out []byte) ([]amdgpuDevice, error) {
+	var cards map[string]rocmSMICard
+	if err := json.Unmarshal(out, &cards); err != nil {
+		return nil, fmt.Errorf("could not parse rocm-smi output: %w", err)
+	}
+
+	devices := make([]amdgpuDevice, 0, len(cards))
+	for name, card := range cards {
+		if !rocmSMICardKey.MatchString(name) {
+			continue
+		}
+
+		devices = append(devices, amdgpuDevice{
+			MinorNumber:       minorNumberFromCardName(name),
+			Name:              card["Card series"],
+			UUID:              card["Unique ID"],
+			DriverVersion:     card["Driver version"],
+			Temperature:       rocmSMIFloat(card["Temperature (Sensor edge) (C)"]),
+			PowerUsage:        rocmSMIFloat(card["Average Graphics Package Power (W)"]),
+			FanSpeed:          rocmSMIFloat(card["Fan speed (%)"]),
+			MemoryTotal:       rocmSMIFloat(card["VRAM Total Memory (B)"]),
+			MemoryUsed:        rocmSMIFloat(card["VRAM Total Used Memory (B)"]),
+			UtilizationMemory: rocmSMIFloat(card["GPU memory use (%)"]),
+			UtilizationGPU:    rocmSMIFloat(card["GPU use (%)"]),
+			ClockSCLK:         rocmSMIClockHertz(card["sclk clock speed"]),
+			ClockMCLK:         rocmSMIClockHertz(card["mclk clock speed"]),
+		})
+	}
+
+	return devices, nil
+}
+
+func minorNumberFromCardName(name string) string {
+	var minor string
+	fmt.Sscanf(name, "card%s", &minor)
+	return minor
+}
+
+func rocmSMIFloat(value string) float64 {
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+// rocmSMIClockHertz converts a "(xxxMhz)" clock reading as reported by rocm-smi into hertz.
+func rocmSMIClockHertz(value string) float64 {
+	var mhz float64
+	fmt.Sscanf(value, "(%fMhz)", &mhz)
+	return mhz * 1e6
+}
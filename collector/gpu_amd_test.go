@@ -0,0 +1,98 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !noamdgpu
+
+package collector
+
+import "testing"
+
+const sampleRocmSMIOutput = `{
+	"card0": {
+		"Card series": "Instinct MI100",
+		"Unique ID": "0x1234",
+		"Driver version": "5.11.0",
+		"Temperature (Sensor edge) (C)": "52.0",
+		"Average Graphics Package Power (W)": "120.0",
+		"Fan speed (%)": "35",
+		"VRAM Total Memory (B)": "34342961152",
+		"VRAM Total Used Memory (B)": "1048576",
+		"GPU memory use (%)": "4",
+		"GPU use (%)": "17",
+		"sclk clock speed": "(1502Mhz)",
+		"mclk clock speed": "(1200Mhz)"
+	},
+	"system": {
+		"Driver version": "5.11.0"
+	}
+}`
+
+func TestParseRocmSMIOutput(t *testing.T) {
+	devices, err := parseRocmSMIOutput([]byte(sampleRocmSMIOutput))
+	if err != nil {
+		t.Fatalf("parseRocmSMIOutput returned error: %v", err)
+	}
+
+	if len(devices) != 1 {
+		t.Fatalf("parseRocmSMIOutput returned %d devices, want 1 (non-card key should be skipped)", len(devices))
+	}
+
+	got := devices[0]
+	if got.MinorNumber != "0" {
+		t.Errorf("MinorNumber = %q, want %q", got.MinorNumber, "0")
+	}
+	if got.Name != "Instinct MI100" {
+		t.Errorf("Name = %q, want %q", got.Name, "Instinct MI100")
+	}
+	if got.Temperature != 52 {
+		t.Errorf("Temperature = %v, want 52", got.Temperature)
+	}
+	if got.UtilizationGPU != 17 {
+		t.Errorf("UtilizationGPU = %v, want 17", got.UtilizationGPU)
+	}
+	if got.ClockSCLK != 1502e6 {
+		t.Errorf("ClockSCLK = %v, want %v", got.ClockSCLK, 1502e6)
+	}
+}
+
+func TestMinorNumberFromCardName(t *testing.T) {
+	tests := map[string]string{
+		"card0": "0",
+		"card7": "7",
+	}
+	for name, want := range tests {
+		if got := minorNumberFromCardName(name); got != want {
+			t.Errorf("minorNumberFromCardName(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestRocmSMIClockHertz(t *testing.T) {
+	tests := []struct {
+		value string
+		want  float64
+	}{
+		{"(1502Mhz)", 1502e6},
+		{"(0Mhz)", 0},
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := rocmSMIClockHertz(tt.value); got != tt.want {
+			t.Errorf("rocmSMIClockHertz(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -20,18 +20,29 @@
 package collector
 
 import (
+	"fmt"
 	"strconv"
 	"time"
 
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/prometheus/client_golang/prometheus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
 
-	"github.com/mindprince/gonvml"
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
 )
 
 var (
 	averageDuration = 10 * time.Second
+	// maxNVLinks is the highest NVLink index queried per device; link indices whose
+	// state query fails (invalid or unsupported) are skipped, while links reported as
+	// disabled are exported as down.
+	maxNVLinks = 18
+
+	gpuLegacyNames = kingpin.Flag(
+		"collector.gpu.legacy-names",
+		"Expose GPU metrics under their pre-1.x names, with system_driver_version kept as a per-series label instead of gpu_info.",
+	).Default("false").Bool()
 )
 
 type gpuCollector struct {
@@ -46,14 +57,40 @@ type gpuCollector struct {
 	gpuUtilizationMemory     *prometheus.Desc
 	gpuUtilizationGPU        *prometheus.Desc
 	gpuUtilizationGPUAverage *prometheus.Desc
+	gpuInfo                  *prometheus.Desc
+	gpuFrequency             *prometheus.Desc
+	gpuPowerMilliwatts       *prometheus.Desc
+	gpuNVLinkUp              *prometheus.Desc
+	gpuProcessMemoryUsed     *prometheus.Desc
+	gpuProcessSMUtilization  *prometheus.Desc
+	gpuProcessRunning        *prometheus.Desc
+	backend                  gpuBackend
 	logger                   log.Logger
 }
 
+// gpuBackend abstracts over the GPU telemetry source: NVML on discrete NVIDIA cards,
+// or tegrastats on Jetson/Tegra boards where NVML is unavailable.
+type gpuBackend interface {
+	Collect() (*gpuMetrics, error)
+	Close()
+}
+
+type nvmlBackend struct{}
+
+func (nvmlBackend) Collect() (*gpuMetrics, error) { return collectMetricDevice() }
+func (nvmlBackend) Close()                        {}
+
+type gpuNVLink struct {
+	Link int
+	Up   bool
+}
+
 type gpuDevice struct {
 	Index                 string
 	MinorNumber           string
 	Name                  string
 	UUID                  string
+	SubtypeID             string
 	Temperature           float64
 	PowerUsage            float64
 	FanSpeed              float64
@@ -62,11 +99,21 @@ type gpuDevice struct {
 	UtilizationMemory     float64
 	UtilizationGPU        float64
 	UtilizationGPUAverage float64
+	NVLinks               []gpuNVLink
+	// NVLinkOnly marks a synthetic entry that carries only the physical GPU's NVLink
+	// state, used to attach NVLink metrics to the parent device once when its children
+	// are MIG instances (NVLink is a property of the physical card, not of a partition).
+	NVLinkOnly      bool
+	Processes       []gpuProcess
+	IsTegra         bool
+	FrequencyHertz  float64
+	PowerMilliwatts float64
 }
 
 type gpuMetrics struct {
-	Version string
-	Devices []gpuDevice
+	Version     string
+	CudaVersion string
+	Devices     []gpuDevice
 }
 
 func init() {
@@ -76,167 +123,430 @@ func init() {
 // NewGPUCollector returns a new Collector exposing CPU stats.
 func NewGPUCollector(logger log.Logger) (Collector, error) {
 	subsystem := "gpu"
+
+	// Under --collector.gpu.legacy-names, series keep the pre-1.x metric names and
+	// carry system_driver_version directly so existing dashboards built against those
+	// names keep working for one release; otherwise driver/CUDA version and the GPU
+	// name move into the gpu_info series below, to avoid series churn on driver upgrades.
+	labels := []string{"minornumber", "uuid", "subtype_id"}
+	metricName := func(modern, legacy string) string { return modern }
+	if *gpuLegacyNames {
+		labels = []string{"minornumber", "name", "uuid", "system_driver_version", "subtype_id"}
+		metricName = func(modern, legacy string) string { return legacy }
+	}
+	nvlinkLabels := append(append([]string{}, labels...), "link")
+
 	return &gpuCollector{
 		gpuTemperature: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "Temperature"),
+			prometheus.BuildFQName(namespace, subsystem, metricName("temperature_celsius", "Temperature")),
 			"Temperature of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			labels, nil,
 		),
 		gpuPowerUsage: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "PowerUsage"),
+			prometheus.BuildFQName(namespace, subsystem, metricName("power_usage_watts", "PowerUsage")),
 			"Power Usage of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			labels, nil,
 		),
 		gpuFanSpeed: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "FanSpeed"),
-			"Fan Speed of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			prometheus.BuildFQName(namespace, subsystem, metricName("fan_speed_ratio", "FanSpeed")),
+			"Fan Speed of GPU device in system, as a ratio between 0 and 1 (0-100 under --collector.gpu.legacy-names)",
+			labels, nil,
 		),
 		gpuMemoryTotal: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "MemoryTotal_Bytes"),
+			prometheus.BuildFQName(namespace, subsystem, metricName("memory_total_bytes", "MemoryTotal_Bytes")),
 			"Memory Total of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			labels, nil,
 		),
 		gpuMemoryUsed: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "MemoryUsed_Bytes"),
+			prometheus.BuildFQName(namespace, subsystem, metricName("memory_used_bytes", "MemoryUsed_Bytes")),
 			"Memory Used of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			labels, nil,
 		),
 		gpuUtilizationMemory: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "UtilizationMemory"),
-			"Utilization Memory of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			prometheus.BuildFQName(namespace, subsystem, metricName("memory_utilization_ratio", "UtilizationMemory")),
+			"Memory Utilization of GPU device in system, as a ratio between 0 and 1 (0-100 under --collector.gpu.legacy-names)",
+			labels, nil,
 		),
 		gpuUtilizationGPU: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "UtilizationGPU"),
-			"Utilization of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			prometheus.BuildFQName(namespace, subsystem, metricName("utilization_ratio", "UtilizationGPU")),
+			"Utilization of GPU device in system, as a ratio between 0 and 1 (0-100 under --collector.gpu.legacy-names)",
+			labels, nil,
 		),
 		gpuUtilizationGPUAverage: prometheus.NewDesc(
-			prometheus.BuildFQName(namespace, subsystem, "UtilizationGPUAverage"),
-			"Utilization Average of GPU device in system",
-			[]string{"minornumber", "name", "uuid", "system_driver_version"}, nil,
+			prometheus.BuildFQName(namespace, subsystem, metricName("utilization_average_ratio", "UtilizationGPUAverage")),
+			"Utilization Average of GPU device in system, as a ratio between 0 and 1 (0-100 under --collector.gpu.legacy-names)",
+			labels, nil,
+		),
+		gpuInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "info"),
+			"A constant 1-valued metric with a label for each piece of GPU device/driver metadata",
+			[]string{"uuid", "name", "driver_version", "cuda_version"}, nil,
+		),
+		gpuFrequency: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "frequency_hertz"),
+			"GPU clock frequency, reported on backends that expose it directly (e.g. tegrastats)",
+			labels, nil,
+		),
+		gpuPowerMilliwatts: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "power_milliwatts"),
+			"GPU rail power draw in milliwatts, reported on backends that expose it directly (e.g. tegrastats)",
+			labels, nil,
+		),
+		gpuNVLinkUp: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "nvlink_link_up"),
+			"Whether this NVLink link is active (1) or not (0)",
+			nvlinkLabels, nil,
+		),
+		gpuProcessMemoryUsed: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "process_memory_used_bytes"),
+			"GPU memory used by this process",
+			[]string{"pid", "process_name", "minornumber", "uuid", "container_id", "pod_uid"}, nil,
+		),
+		gpuProcessSMUtilization: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "process_sm_utilization"),
+			"SM (compute) utilization attributable to this process",
+			[]string{"pid", "process_name", "minornumber", "uuid", "container_id", "pod_uid"}, nil,
 		),
-		logger: logger,
+		gpuProcessRunning: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, subsystem, "process_running"),
+			"1 if this process currently holds a GPU compute context",
+			[]string{"pid", "process_name", "minornumber", "uuid", "container_id", "pod_uid"}, nil,
+		),
+		backend: selectGPUBackend(logger),
+		logger:  logger,
 	}, nil
 }
 
+// selectGPUBackend probes for NVML first, since it is the richest backend, and falls
+// back to tegrastats on Jetson/Tegra boards that don't ship NVML at all. If neither is
+// available the collector is left with no backend and simply reports nothing.
+func selectGPUBackend(logger log.Logger) gpuBackend {
+	if ret := nvml.Init(); ret == nvml.SUCCESS {
+		nvml.Shutdown()
+		return nvmlBackend{}
+	}
+
+	if tegraAvailable() {
+		backend, err := newTegraBackend(logger)
+		if err != nil {
+			level.Warn(logger).Log("msg", "tegrastats is present but could not be started", "err", err)
+			return nil
+		}
+		return backend
+	}
+
+	return nil
+}
+
 func (g *gpuCollector) Update(ch chan<- prometheus.Metric) error {
-	gpu, err := collectMetricDevice()
+	if g.backend == nil {
+		level.Debug(g.logger).Log("msg", "no GPU backend available on this host")
+		return nil
+	}
+
+	gpu, err := g.backend.Collect()
 	if err != nil {
 		level.Debug(g.logger).Log("msg", "gpu information is unavailable to collect")
 		return nil
 	}
 
+	// Ratios were historically reported upstream as 0-100 percentages; the modern,
+	// non-legacy names follow Prometheus convention and report 0-1 instead.
+	ratioScale := 0.01
+	if *gpuLegacyNames {
+		ratioScale = 1
+	}
+
 	for _, metrics := range gpu.Devices {
+		var labelValues []string
+		if *gpuLegacyNames {
+			labelValues = []string{metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version, metrics.SubtypeID}
+		} else {
+			labelValues = []string{metrics.MinorNumber, metrics.UUID, metrics.SubtypeID}
+		}
+
+		if metrics.NVLinkOnly {
+			g.collectNVLinkMetrics(ch, metrics, labelValues)
+			continue
+		}
+
+		if !*gpuLegacyNames {
+			ch <- prometheus.MustNewConstMetric(
+				g.gpuInfo, prometheus.GaugeValue, 1, metrics.UUID, metrics.Name, gpu.Version, gpu.CudaVersion)
+		}
+
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuTemperature, prometheus.GaugeValue, metrics.Temperature, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuTemperature, prometheus.GaugeValue, metrics.Temperature, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuPowerUsage, prometheus.GaugeValue, metrics.PowerUsage, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuPowerUsage, prometheus.GaugeValue, metrics.PowerUsage, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuFanSpeed, prometheus.GaugeValue, metrics.FanSpeed, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuFanSpeed, prometheus.GaugeValue, metrics.FanSpeed*ratioScale, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuMemoryTotal, prometheus.CounterValue, metrics.MemoryTotal, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuMemoryTotal, prometheus.GaugeValue, metrics.MemoryTotal, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuMemoryUsed, prometheus.GaugeValue, metrics.MemoryUsed, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuMemoryUsed, prometheus.GaugeValue, metrics.MemoryUsed, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuUtilizationMemory, prometheus.GaugeValue, metrics.UtilizationMemory, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuUtilizationMemory, prometheus.GaugeValue, metrics.UtilizationMemory*ratioScale, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuUtilizationGPU, prometheus.GaugeValue, metrics.UtilizationGPU, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuUtilizationGPU, prometheus.GaugeValue, metrics.UtilizationGPU*ratioScale, labelValues...)
 		ch <- prometheus.MustNewConstMetric(
-			g.gpuUtilizationGPUAverage, prometheus.GaugeValue, metrics.UtilizationGPUAverage, metrics.MinorNumber, metrics.Name, metrics.UUID, gpu.Version)
+			g.gpuUtilizationGPUAverage, prometheus.GaugeValue, metrics.UtilizationGPUAverage*ratioScale, labelValues...)
+
+		if metrics.IsTegra {
+			ch <- prometheus.MustNewConstMetric(
+				g.gpuFrequency, prometheus.GaugeValue, metrics.FrequencyHertz, labelValues...)
+			ch <- prometheus.MustNewConstMetric(
+				g.gpuPowerMilliwatts, prometheus.GaugeValue, metrics.PowerMilliwatts, labelValues...)
+		}
+
+		g.collectNVLinkMetrics(ch, metrics, labelValues)
+
+		g.collectProcessMetrics(ch, metrics, metrics.MinorNumber, metrics.UUID)
 	}
 
 	return nil
 }
 
+// collectNVLinkMetrics emits the link-up gauge for every NVLink reported on metrics.
+// NVLink state belongs to the physical GPU, so for MIG-enabled devices links are only
+// emitted once, via the synthetic NVLinkOnly entry collectDeviceOrMigInstances attaches
+// to the parent device; the per-partition entries carry no NVLinks and emit nothing here.
+func (g *gpuCollector) collectNVLinkMetrics(ch chan<- prometheus.Metric, metrics gpuDevice, labelValues []string) {
+	for _, link := range metrics.NVLinks {
+		linkLabelValues := append(append([]string{}, labelValues...), strconv.Itoa(link.Link))
+		up := 0.0
+		if link.Up {
+			up = 1.0
+		}
+		ch <- prometheus.MustNewConstMetric(
+			g.gpuNVLinkUp, prometheus.GaugeValue, up, linkLabelValues...)
+	}
+}
+
 func collectMetricDevice() (*gpuMetrics, error) {
-	if err := gonvml.Initialize(); err != nil {
-		return nil, err
+	if ret := nvml.Init(); ret != nvml.SUCCESS {
+		return nil, nvml.ErrorString(ret)
 	}
-	defer gonvml.Shutdown()
+	defer nvml.Shutdown()
 
-	version, err := gonvml.SystemDriverVersion()
-	if err != nil {
-		return nil, err
+	version, ret := nvml.SystemGetDriverVersion()
+	if ret != nvml.SUCCESS {
+		return nil, nvml.ErrorString(ret)
+	}
+
+	cudaVersion, ret := nvml.SystemGetCudaDriverVersion()
+	if ret != nvml.SUCCESS {
+		return nil, nvml.ErrorString(ret)
 	}
 
 	metrics := &gpuMetrics{
-		Version: version,
+		Version:     version,
+		CudaVersion: formatCudaVersion(cudaVersion),
 	}
 
-	numDevices, err := gonvml.DeviceCount()
-	if err != nil {
-		return nil, err
+	numDevices, ret := nvml.DeviceGetCount()
+	if ret != nvml.SUCCESS {
+		return nil, nvml.ErrorString(ret)
 	}
 
-	for index := 0; index < int(numDevices); index++ {
-		device, err := gonvml.DeviceHandleByIndex(uint(index))
-		if err != nil {
-			return nil, err
+	for index := 0; index < numDevices; index++ {
+		device, ret := nvml.DeviceGetHandleByIndex(index)
+		if ret != nvml.SUCCESS {
+			return nil, nvml.ErrorString(ret)
 		}
 
-		uuid, err := device.UUID()
+		devices, err := collectDeviceOrMigInstances(device, index)
 		if err != nil {
 			return nil, err
 		}
 
-		name, err := device.Name()
-		if err != nil {
-			return nil, err
-		}
+		metrics.Devices = append(metrics.Devices, devices...)
+	}
 
-		minorNumber, err := device.MinorNumber()
-		if err != nil {
-			return nil, err
-		}
+	return metrics, nil
+}
 
-		temperature, err := device.Temperature()
-		if err != nil {
-			return nil, err
+// collectDeviceOrMigInstances collects metrics for device. When MIG mode is enabled on
+// the device, it instead enumerates each MIG instance as its own series, labelled by
+// the MIG instance UUID via SubtypeID, plus one synthetic NVLinkOnly entry carrying the
+// physical GPU's NVLink state (NVLink belongs to the card, not to a MIG partition, and
+// querying it through a MIG instance handle is not supported); otherwise it falls back
+// to the parent device.
+func collectDeviceOrMigInstances(device nvml.Device, index int) ([]gpuDevice, error) {
+	currentMode, _, ret := device.GetMigMode()
+	if ret == nvml.SUCCESS && currentMode == nvml.DEVICE_MIG_ENABLE {
+		maxMigDevices, ret := device.GetMaxMigDeviceCount()
+		if ret != nvml.SUCCESS {
+			return nil, nvml.ErrorString(ret)
 		}
 
-		powerUsage, err := device.PowerUsage()
-		if err != nil {
-			return nil, err
-		}
+		var devices []gpuDevice
+		for migIndex := 0; migIndex < maxMigDevices; migIndex++ {
+			migDevice, ret := device.GetMigDeviceHandleByIndex(migIndex)
+			if ret == nvml.ERROR_NOT_FOUND {
+				continue
+			}
+			if ret != nvml.SUCCESS {
+				return nil, nvml.ErrorString(ret)
+			}
 
-		fanSpeed, err := device.FanSpeed()
-		if err != nil {
-			return nil, err
-		}
+			migUUID, ret := migDevice.GetUUID()
+			if ret != nvml.SUCCESS {
+				return nil, nvml.ErrorString(ret)
+			}
 
-		memoryTotal, memoryUsed, err := device.MemoryInfo()
-		if err != nil {
-			return nil, err
+			gpuDev, err := collectDevice(migDevice, index, false)
+			if err != nil {
+				return nil, err
+			}
+			gpuDev.SubtypeID = migUUID
+			devices = append(devices, gpuDev)
 		}
 
-		utilizationGPU, utilizationMemory, err := device.UtilizationRates()
+		nvlinkDev, err := collectPhysicalNVLinks(device, index)
 		if err != nil {
 			return nil, err
 		}
+		devices = append([]gpuDevice{nvlinkDev}, devices...)
 
-		utilizationGPUAverage, err := device.AverageGPUUtilization(averageDuration)
-		if err != nil {
-			return nil, err
-		}
+		return devices, nil
+	}
 
-		metrics.Devices = append(metrics.Devices,
-			gpuDevice{
-				Index:                 strconv.Itoa(index),
-				MinorNumber:           strconv.Itoa(int(minorNumber)),
-				Name:                  name,
-				UUID:                  uuid,
-				Temperature:           float64(temperature),
-				PowerUsage:            float64(powerUsage),
-				FanSpeed:              float64(fanSpeed),
-				MemoryTotal:           float64(memoryTotal),
-				MemoryUsed:            float64(memoryUsed),
-				UtilizationMemory:     float64(utilizationMemory),
-				UtilizationGPU:        float64(utilizationGPU),
-				UtilizationGPUAverage: float64(utilizationGPUAverage),
-			})
+	gpuDev, err := collectDevice(device, index, true)
+	if err != nil {
+		return nil, err
 	}
+	return []gpuDevice{gpuDev}, nil
+}
 
-	return metrics, nil
+// collectPhysicalNVLinks returns a synthetic NVLinkOnly gpuDevice carrying the physical
+// device's NVLink state, for attaching to the parent series when device's children are
+// MIG instances.
+func collectPhysicalNVLinks(device nvml.Device, index int) (gpuDevice, error) {
+	uuid, ret := device.GetUUID()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	name, ret := device.GetName()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	minorNumber, ret := device.GetMinorNumber()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	return gpuDevice{
+		Index:       strconv.Itoa(index),
+		MinorNumber: strconv.Itoa(minorNumber),
+		Name:        name,
+		UUID:        uuid,
+		NVLinks:     collectNVLinks(device),
+		NVLinkOnly:  true,
+	}, nil
+}
+
+func collectDevice(device nvml.Device, index int, withNVLinks bool) (gpuDevice, error) {
+	uuid, ret := device.GetUUID()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	name, ret := device.GetName()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	minorNumber, ret := device.GetMinorNumber()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	temperature, ret := device.GetTemperature(nvml.TEMPERATURE_GPU)
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	powerUsage, ret := device.GetPowerUsage()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	fanSpeed, ret := device.GetFanSpeed()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	memory, ret := device.GetMemoryInfo()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	utilization, ret := device.GetUtilizationRates()
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	utilizationGPUAverage, ret := device.GetAverageGpuUtilization(averageDuration)
+	if ret != nvml.SUCCESS {
+		return gpuDevice{}, nvml.ErrorString(ret)
+	}
+
+	processes, err := collectDeviceProcesses(device)
+	if err != nil {
+		return gpuDevice{}, err
+	}
+
+	var links []gpuNVLink
+	if withNVLinks {
+		links = collectNVLinks(device)
+	}
+
+	return gpuDevice{
+		Index:                 strconv.Itoa(index),
+		MinorNumber:           strconv.Itoa(minorNumber),
+		Name:                  name,
+		UUID:                  uuid,
+		Temperature:           float64(temperature),
+		PowerUsage:            float64(powerUsage),
+		FanSpeed:              float64(fanSpeed),
+		MemoryTotal:           float64(memory.Total),
+		MemoryUsed:            float64(memory.Used),
+		UtilizationMemory:     float64(utilization.Memory),
+		UtilizationGPU:        float64(utilization.Gpu),
+		UtilizationGPUAverage: float64(utilizationGPUAverage),
+		NVLinks:               links,
+		Processes:             processes,
+	}, nil
+}
+
+// formatCudaVersion turns the packed integer NVML returns (e.g. 12020) into the
+// conventional "major.minor" CUDA version string (e.g. "12.2").
+func formatCudaVersion(version int) string {
+	return fmt.Sprintf("%d.%d", version/1000, (version%1000)/10)
+}
+
+// collectNVLinks reports per-link up/down state for device. NVLink utilization byte
+// counters are deliberately not collected here: reading them requires first enabling
+// the counter set via SetNvLinkUtilizationControl, and without that call in place the
+// counters silently read back zero, which is worse than not exposing them at all.
+func collectNVLinks(device nvml.Device) []gpuNVLink {
+	var links []gpuNVLink
+	for link := 0; link < maxNVLinks; link++ {
+		state, ret := device.GetNvLinkState(link)
+		if ret == nvml.ERROR_INVALID_ARGUMENT || ret == nvml.ERROR_NOT_SUPPORTED {
+			continue
+		}
+		if ret != nvml.SUCCESS {
+			continue
+		}
+
+		links = append(links, gpuNVLink{
+			Link: link,
+			Up:   state == nvml.FEATURE_ENABLED,
+		})
+	}
+	return links
 }
@@ -0,0 +1,24 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build nogpu
+
+package collector
+
+// The gpu collector depends on NVML, which requires cgo and the NVIDIA driver
+// headers. This stub keeps the package building under the nogpu tag, where
+// collector/gpu_common.go (and its cgo import) is excluded entirely.
@@ -0,0 +1,146 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !nogpu
+
+package collector
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	kingpin "gopkg.in/alecthomas/kingpin.v2"
+
+	"github.com/NVIDIA/go-nvml/pkg/nvml"
+)
+
+var gpuCollectProcesses = kingpin.Flag(
+	"collector.gpu.processes",
+	"Enable per-process GPU utilization and memory metrics (requires extra NVML privileges).",
+).Default("false").Bool()
+
+// kubepodsCgroup matches both the cgroupfs and systemd cgroup drivers, e.g.
+// "/kubepods/burstable/pod<uid>/<container_id>" and
+// "/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod<uid>.slice/docker-<container_id>.scope".
+// The systemd driver separates the UID's dash groups with underscores instead of dashes,
+// so the capture group must allow both and the match normalized back to dashes.
+var kubepodsCgroup = regexp.MustCompile(`kubepods[^\n]*pod([a-f0-9_-]+)(?:\.slice)?/(?:[a-z-]+-)?([a-f0-9]{64})`)
+
+type gpuProcess struct {
+	PID           int
+	ProcessName   string
+	MemoryUsed    float64
+	SMUtilization float64
+	ContainerID   string
+	PodUID        string
+}
+
+func (g *gpuCollector) collectProcessMetrics(ch chan<- prometheus.Metric, device gpuDevice, minorNumber, uuid string) {
+	if !*gpuCollectProcesses {
+		return
+	}
+
+	for _, process := range device.Processes {
+		labels := []string{
+			strconv.Itoa(process.PID),
+			process.ProcessName,
+			minorNumber,
+			uuid,
+			process.ContainerID,
+			process.PodUID,
+		}
+		ch <- prometheus.MustNewConstMetric(
+			g.gpuProcessMemoryUsed, prometheus.GaugeValue, process.MemoryUsed, labels...)
+		ch <- prometheus.MustNewConstMetric(
+			g.gpuProcessSMUtilization, prometheus.GaugeValue, process.SMUtilization, labels...)
+		ch <- prometheus.MustNewConstMetric(
+			g.gpuProcessRunning, prometheus.GaugeValue, 1, labels...)
+	}
+}
+
+// collectDeviceProcesses enumerates compute processes and their SM utilization for device,
+// joining the two NVML calls on PID and resolving each PID's container/pod via its cgroup.
+func collectDeviceProcesses(device nvml.Device) ([]gpuProcess, error) {
+	if !*gpuCollectProcesses {
+		return nil, nil
+	}
+
+	running, ret := device.GetComputeRunningProcesses()
+	if ret != nvml.SUCCESS {
+		return nil, nvml.ErrorString(ret)
+	}
+
+	utilization, ret := device.GetProcessUtilization(0)
+	if ret != nvml.SUCCESS && ret != nvml.ERROR_NOT_FOUND {
+		return nil, nvml.ErrorString(ret)
+	}
+
+	smUtilByPID := make(map[uint32]uint32, len(utilization))
+	for _, sample := range utilization {
+		smUtilByPID[sample.Pid] = sample.SmUtil
+	}
+
+	processes := make([]gpuProcess, 0, len(running))
+	for _, info := range running {
+		containerID, podUID := processCgroupOwner(int(info.Pid))
+		processes = append(processes, gpuProcess{
+			PID:           int(info.Pid),
+			ProcessName:   processName(int(info.Pid)),
+			MemoryUsed:    float64(info.UsedGpuMemory),
+			SMUtilization: float64(smUtilByPID[info.Pid]),
+			ContainerID:   containerID,
+			PodUID:        podUID,
+		})
+	}
+
+	return processes, nil
+}
+
+func processName(pid int) string {
+	comm, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(comm))
+}
+
+// processCgroupOwner resolves the Kubernetes pod UID and container ID that pid belongs
+// to, by inspecting its kubepods cgroup path. Both are empty when pid is not containerized.
+func processCgroupOwner(pid int) (containerID, podUID string) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	if err != nil {
+		return "", ""
+	}
+
+	return parseCgroupOwner(string(data))
+}
+
+// parseCgroupOwner extracts the container ID and pod UID from the contents of a
+// /proc/<pid>/cgroup file, split out of processCgroupOwner so it can be unit tested
+// against sample cgroup content without a real process.
+func parseCgroupOwner(data string) (containerID, podUID string) {
+	match := kubepodsCgroup.FindStringSubmatch(data)
+	if match == nil {
+		return "", ""
+	}
+
+	return match[2], strings.ReplaceAll(match[1], "_", "-")
+}
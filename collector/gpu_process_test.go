@@ -0,0 +1,62 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !nogpu
+
+package collector
+
+import "testing"
+
+func TestParseCgroupOwner(t *testing.T) {
+	tests := []struct {
+		name            string
+		data            string
+		wantContainerID string
+		wantPodUID      string
+	}{
+		{
+			name:            "cgroupfs driver",
+			data:            "11:devices:/kubepods/burstable/pod12345678-1234-1234-1234-123456789012/abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789\n",
+			wantContainerID: "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+			wantPodUID:      "12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:            "systemd driver",
+			data:            "11:devices:/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod12345678_1234_1234_1234_123456789012.slice/docker-abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789.scope\n",
+			wantContainerID: "abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789",
+			wantPodUID:      "12345678-1234-1234-1234-123456789012",
+		},
+		{
+			name:            "not containerized",
+			data:            "11:devices:/user.slice\n",
+			wantContainerID: "",
+			wantPodUID:      "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotContainerID, gotPodUID := parseCgroupOwner(tt.data)
+			if gotContainerID != tt.wantContainerID {
+				t.Errorf("containerID = %q, want %q", gotContainerID, tt.wantContainerID)
+			}
+			if gotPodUID != tt.wantPodUID {
+				t.Errorf("podUID = %q, want %q", gotPodUID, tt.wantPodUID)
+			}
+		})
+	}
+}
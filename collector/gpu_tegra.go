@@ -0,0 +1,182 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !nogpu
+
+package collector
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+)
+
+const tegrastatsPath = "/usr/bin/tegrastats"
+
+var errNoTegraSampleYet = errors.New("no tegrastats sample collected yet")
+
+var (
+	tegraGR3DPattern     = regexp.MustCompile(`GR3D_FREQ (\d+)%(?:@(\d+))?`)
+	tegraGPUTempPattern  = regexp.MustCompile(`GPU@(-?[\d.]+)C`)
+	tegraGPUPowerPattern = regexp.MustCompile(`POM_5V_GPU (\d+)/\d+`)
+)
+
+type tegraSample struct {
+	UtilizationGPU  float64
+	FrequencyHertz  float64
+	Temperature     float64
+	PowerMilliwatts float64
+}
+
+// tegraBackend spawns `tegrastats` once and keeps the latest parsed sample cached,
+// since invoking it on every scrape would be far slower than the scrape interval.
+type tegraBackend struct {
+	mu      sync.Mutex
+	latest  *tegraSample
+	cmd     *exec.Cmd
+	stopped chan struct{}
+	logger  log.Logger
+}
+
+func tegraAvailable() bool {
+	_, err := os.Stat(tegrastatsPath)
+	return err == nil
+}
+
+func newTegraBackend(logger log.Logger) (*tegraBackend, error) {
+	b := &tegraBackend{
+		stopped: make(chan struct{}),
+		logger:  logger,
+	}
+	if err := b.spawn(); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (b *tegraBackend) spawn() error {
+	intervalMs := strconv.Itoa(int(averageDuration / time.Millisecond))
+	cmd := exec.Command(tegrastatsPath, "--interval", intervalMs)
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	b.cmd = cmd
+	go b.readLoop(stdout)
+	go b.watch()
+
+	return nil
+}
+
+func (b *tegraBackend) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		sample := parseTegrastatsLine(scanner.Text())
+		b.mu.Lock()
+		b.latest = sample
+		b.mu.Unlock()
+	}
+}
+
+// watch respawns tegrastats if it exits on its own, unless we asked it to stop.
+func (b *tegraBackend) watch() {
+	err := b.cmd.Wait()
+
+	select {
+	case <-b.stopped:
+		return
+	default:
+	}
+
+	level.Warn(b.logger).Log("msg", "tegrastats exited unexpectedly, respawning", "err", err)
+	if err := b.spawn(); err != nil {
+		level.Error(b.logger).Log("msg", "failed to respawn tegrastats", "err", err)
+	}
+}
+
+func (b *tegraBackend) Collect() (*gpuMetrics, error) {
+	b.mu.Lock()
+	sample := b.latest
+	b.mu.Unlock()
+
+	if sample == nil {
+		return nil, errNoTegraSampleYet
+	}
+
+	return &gpuMetrics{
+		Devices: []gpuDevice{
+			{
+				Index:           "0",
+				MinorNumber:     "0",
+				Name:            "tegra",
+				IsTegra:         true,
+				UtilizationGPU:  sample.UtilizationGPU,
+				FrequencyHertz:  sample.FrequencyHertz,
+				Temperature:     sample.Temperature,
+				PowerMilliwatts: sample.PowerMilliwatts,
+			},
+		},
+	}, nil
+}
+
+func (b *tegraBackend) Close() {
+	close(b.stopped)
+	if b.cmd != nil && b.cmd.Process != nil {
+		b.cmd.Process.Signal(syscall.SIGTERM)
+	}
+}
+
+// parseTegrastatsLine pulls the fields we care about out of one tegrastats output line,
+// e.g. "RAM 2520/3956MB ... GR3D_FREQ 12%@998 ... GPU@41C ... POM_5V_GPU 401/611".
+func parseTegrastatsLine(line string) *tegraSample {
+	sample := &tegraSample{}
+
+	if m := tegraGPUTempPattern.FindStringSubmatch(line); m != nil {
+		sample.Temperature, _ = strconv.ParseFloat(m[1], 64)
+	}
+
+	if m := tegraGR3DPattern.FindStringSubmatch(line); m != nil {
+		// Stored as a raw 0-100 percentage, same as NVML's utilization rates, so the
+		// shared ratioScale in gpuCollector.Update applies uniformly to both backends.
+		sample.UtilizationGPU, _ = strconv.ParseFloat(m[1], 64)
+		if len(m) > 2 && m[2] != "" {
+			mhz, _ := strconv.ParseFloat(m[2], 64)
+			sample.FrequencyHertz = mhz * 1e6
+		}
+	}
+
+	if m := tegraGPUPowerPattern.FindStringSubmatch(line); m != nil {
+		sample.PowerMilliwatts, _ = strconv.ParseFloat(m[1], 64)
+	}
+
+	return sample
+}
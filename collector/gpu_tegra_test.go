@@ -0,0 +1,70 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build !nogpu
+
+package collector
+
+import "testing"
+
+func TestParseTegrastatsLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want tegraSample
+	}{
+		{
+			name: "full line with GR3D frequency",
+			line: "RAM 2520/3956MB (lfb 4x4MB) SWAP 0/1978MB (cached 0MB) CPU [12%@1190,8%@1190] GR3D_FREQ 12%@998 GPU@41C PMIC@100C AO@46C thermal@40.5C POM_5V_IN 1853/1853 POM_5V_GPU 401/401 POM_5V_CPU 200/200",
+			want: tegraSample{
+				UtilizationGPU:  12,
+				FrequencyHertz:  998e6,
+				Temperature:     41,
+				PowerMilliwatts: 401,
+			},
+		},
+		{
+			name: "GR3D without frequency suffix",
+			line: "GR3D_FREQ 0% GPU@38.5C",
+			want: tegraSample{
+				UtilizationGPU: 0,
+				Temperature:    38.5,
+			},
+		},
+		{
+			name: "power only",
+			line: "POM_5V_GPU 611/733",
+			want: tegraSample{
+				PowerMilliwatts: 611,
+			},
+		},
+		{
+			name: "no matching fields",
+			line: "RAM 2520/3956MB (lfb 4x4MB)",
+			want: tegraSample{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseTegrastatsLine(tt.line)
+			if *got != tt.want {
+				t.Errorf("parseTegrastatsLine(%q) = %+v, want %+v", tt.line, *got, tt.want)
+			}
+		})
+	}
+}
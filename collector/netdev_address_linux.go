@@ -0,0 +1,110 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build linux,!nonetdev
+
+package collector
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/go-kit/kit/log"
+	"github.com/go-kit/kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/vishvananda/netlink"
+)
+
+// netDevAddressInfoCollector exposes node_network_address_info{device,address,netmask,scope},
+// letting PromQL join an IP address back to the interface that owns it. It reads addresses
+// via netlink rather than /proc/net/fib_trie, since fib_trie entries aren't attributed to
+// a device name. It honours the same --collector.netdev.device-exclude/-include filter as
+// the netdev collector, so container/CNI veth churn doesn't blow up its cardinality either.
+type netDevAddressInfoCollector struct {
+	addressInfo  *prometheus.Desc
+	deviceFilter netDevFilter
+	logger       log.Logger
+}
+
+func init() {
+	registerCollector("netdev.address-info", defaultDisabled, NewNetDevAddressInfoCollector)
+}
+
+// NewNetDevAddressInfoCollector returns a new Collector exposing per-interface address info.
+func NewNetDevAddressInfoCollector(logger log.Logger) (Collector, error) {
+	filter, err := newNetDevFilter(*netdevDeviceExclude, *netdevDeviceInclude)
+	if err != nil {
+		return nil, fmt.Errorf("could not build netdev device filter: %w", err)
+	}
+
+	return &netDevAddressInfoCollector{
+		addressInfo: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "network", "address_info"),
+			"Address information per network interface",
+			[]string{"device", "address", "netmask", "scope"}, nil,
+		),
+		deviceFilter: filter,
+		logger:       logger,
+	}, nil
+}
+
+func (c *netDevAddressInfoCollector) Update(ch chan<- prometheus.Metric) error {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return fmt.Errorf("could not list network links: %w", err)
+	}
+
+	for _, link := range links {
+		device := link.Attrs().Name
+		if c.deviceFilter.ignored(device) {
+			level.Debug(c.logger).Log("msg", "Ignoring device", "device", device)
+			continue
+		}
+
+		addrs, err := netlink.AddrList(link, netlink.FAMILY_ALL)
+		if err != nil {
+			level.Debug(c.logger).Log("msg", "could not list addresses for device", "device", device, "err", err)
+			continue
+		}
+
+		for _, addr := range addrs {
+			netmask := net.IP(addr.IPNet.Mask).String()
+			ch <- prometheus.MustNewConstMetric(
+				c.addressInfo, prometheus.GaugeValue, 1,
+				device, addr.IP.String(), netmask, addrScopeString(addr.Scope))
+		}
+	}
+
+	return nil
+}
+
+func addrScopeString(scope int) string {
+	switch netlink.Scope(scope) {
+	case netlink.SCOPE_UNIVERSE:
+		return "global"
+	case netlink.SCOPE_SITE:
+		return "site"
+	case netlink.SCOPE_LINK:
+		return "link"
+	case netlink.SCOPE_HOST:
+		return "host"
+	case netlink.SCOPE_NOWHERE:
+		return "nowhere"
+	default:
+		return "unknown"
+	}
+}
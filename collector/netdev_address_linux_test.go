@@ -0,0 +1,46 @@
+// This file is part of bizfly-agent
+//
+// Copyright (C) 2020  BizFly Cloud
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>
+
+// +build linux,!nonetdev
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/vishvananda/netlink"
+)
+
+func TestAddrScopeString(t *testing.T) {
+	tests := []struct {
+		scope netlink.Scope
+		want  string
+	}{
+		{netlink.SCOPE_UNIVERSE, "global"},
+		{netlink.SCOPE_SITE, "site"},
+		{netlink.SCOPE_LINK, "link"},
+		{netlink.SCOPE_HOST, "host"},
+		{netlink.SCOPE_NOWHERE, "nowhere"},
+		{netlink.Scope(99), "unknown"},
+	}
+
+	for _, tt := range tests {
+		if got := addrScopeString(int(tt.scope)); got != tt.want {
+			t.Errorf("addrScopeString(%d) = %q, want %q", tt.scope, got, tt.want)
+		}
+	}
+}
@@ -16,8 +16,6 @@
 package collector
 
 import (
-	"encoding/json"
-
 	"github.com/go-kit/kit/log"
 	"github.com/go-kit/kit/log/level"
 	"github.com/shirou/gopsutil/net"
@@ -43,26 +41,31 @@ func parseNetDevStats(ni []net.IOCountersStat, filter *netDevFilter, logger log.
 			continue
 		}
 
-		statistic, err := parseToString(net)
-		if err != nil {
-			return nil, err
-		}
-		netDev[dev] = statistic
+		netDev[dev] = mapIOCounters(net)
 	}
 	return netDev, nil
 }
 
-func parseToString(data net.IOCountersStat) (map[string]uint64, error) {
-	statistic := make(map[string]uint64)
-
-	statsBytes, err := json.Marshal(data)
-	if err != nil {
-		return nil, err
+// mapIOCounters maps an IOCountersStat onto the same receive_*/transmit_* counter names
+// the Linux procfs netdev collector uses upstream, reading each field directly instead of
+// round-tripping through JSON. gopsutil does not surface frame/compressed/multicast
+// counters on this platform, so those are reported as zero rather than omitted, to keep
+// a stable set of series across platforms.
+func mapIOCounters(data net.IOCountersStat) map[string]uint64 {
+	return map[string]uint64{
+		"receive_bytes":       data.BytesRecv,
+		"receive_packets":     data.PacketsRecv,
+		"receive_errs":        data.Errin,
+		"receive_drop":        data.Dropin,
+		"receive_fifo":        data.Fifoin,
+		"receive_frame":       0,
+		"receive_compressed":  0,
+		"receive_multicast":   0,
+		"transmit_bytes":      data.BytesSent,
+		"transmit_packets":    data.PacketsSent,
+		"transmit_errs":       data.Errout,
+		"transmit_drop":       data.Dropout,
+		"transmit_fifo":       data.Fifoout,
+		"transmit_compressed": 0,
 	}
-	json.Unmarshal(statsBytes, &statistic)
-
-	// Ignore field name in statistic map
-	delete(statistic, "name")
-
-	return statistic, nil
 }
@@ -0,0 +1,66 @@
+// Copyright 2020 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build !nonetdev
+
+package collector
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/net"
+)
+
+func TestMapIOCounters(t *testing.T) {
+	data := net.IOCountersStat{
+		Name:        "eth0",
+		BytesRecv:   100,
+		PacketsRecv: 10,
+		Errin:       1,
+		Dropin:      2,
+		Fifoin:      3,
+		BytesSent:   200,
+		PacketsSent: 20,
+		Errout:      4,
+		Dropout:     5,
+		Fifoout:     6,
+	}
+
+	got := mapIOCounters(data)
+
+	want := map[string]uint64{
+		"receive_bytes":       100,
+		"receive_packets":     10,
+		"receive_errs":        1,
+		"receive_drop":        2,
+		"receive_fifo":        3,
+		"receive_frame":       0,
+		"receive_compressed":  0,
+		"receive_multicast":   0,
+		"transmit_bytes":      200,
+		"transmit_packets":    20,
+		"transmit_errs":       4,
+		"transmit_drop":       5,
+		"transmit_fifo":       6,
+		"transmit_compressed": 0,
+	}
+
+	for key, wantValue := range want {
+		if got[key] != wantValue {
+			t.Errorf("mapIOCounters(...)[%q] = %d, want %d", key, got[key], wantValue)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("mapIOCounters(...) returned %d keys, want %d", len(got), len(want))
+	}
+}